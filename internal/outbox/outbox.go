@@ -0,0 +1,208 @@
+// Package outbox is a BoltDB-backed write-ahead log for in-flight
+// SubmitSM traffic. Session previously tracked this in an in-memory
+// map[int32]*MessageStatus and a 50-slot resend channel, so a crash lost
+// both the in-flight state and the ability to correlate a later delivery
+// receipt back to its submission; Outbox survives both.
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// ErrNotFound is returned when an entry can't be located by sequence
+// number or SMSC message ID.
+var ErrNotFound = errors.New("outbox: entry not found")
+
+var (
+	bucketPending    = []byte("pending")
+	bucketDeadLetter = []byte("dead_letter")
+)
+
+// Entry is the WAL record written for every SubmitSM once gosmpp has
+// accepted it.
+type Entry struct {
+	Sequence   int32     `json:"sequence"`
+	MessageID  string    `json:"message_id"`
+	Sender     string    `json:"sender"`
+	Text       string    `json:"text"`
+	Number     string    `json:"number"`
+	Gateway    string    `json:"gateway"`
+	SubmitTime time.Time `json:"submit_time"`
+	Retries    int       `json:"retries"`
+}
+
+// Outbox is a BoltDB-backed WAL for outstanding submits, keyed by SMPP
+// sequence number so SubmitSMResp/DeliverSM handlers can correlate back to
+// the original submission after a restart.
+type Outbox struct {
+	db         *bbolt.DB
+	maxRetries int
+}
+
+// Open creates/opens the outbox at path, creating its buckets if needed.
+func Open(path string, maxRetries int) (*Outbox, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open outbox %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(bucketPending); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(bucketDeadLetter)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("init outbox buckets: %w", err)
+	}
+
+	return &Outbox{db: db, maxRetries: maxRetries}, nil
+}
+
+// Close releases the underlying BoltDB file.
+func (o *Outbox) Close() error {
+	return o.db.Close()
+}
+
+func seqKey(seq int32) []byte {
+	return []byte(fmt.Sprintf("%010d", uint32(seq)))
+}
+
+// Put writes e to the WAL under its sequence number. Session calls it right
+// after gosmpp has accepted the submit, not before.
+func (o *Outbox) Put(e Entry) error {
+	buf, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return o.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucketPending).Put(seqKey(e.Sequence), buf)
+	})
+}
+
+// UpdateMessageID records the SMSC-assigned message ID once SubmitSMResp
+// arrives, so a later DeliverSM can be correlated back to this entry.
+func (o *Outbox) UpdateMessageID(seq int32, messageID string) error {
+	return o.mutate(seq, func(e *Entry) { e.MessageID = messageID })
+}
+
+// MarkDelivered locates the pending entry for messageID and removes it
+// from the WAL once its delivery receipt has been correlated.
+func (o *Outbox) MarkDelivered(messageID string) error {
+	var key []byte
+	err := o.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucketPending).ForEach(func(k, v []byte) error {
+			var e Entry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			if e.MessageID == messageID {
+				key = append([]byte(nil), k...)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return ErrNotFound
+	}
+
+	return o.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucketPending).Delete(key)
+	})
+}
+
+// mutate loads the entry for seq, applies fn, and persists the result.
+func (o *Outbox) mutate(seq int32, fn func(*Entry)) error {
+	return o.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(bucketPending)
+		key := seqKey(seq)
+		buf := bucket.Get(key)
+		if buf == nil {
+			return ErrNotFound
+		}
+
+		var e Entry
+		if err := json.Unmarshal(buf, &e); err != nil {
+			return err
+		}
+		fn(&e)
+
+		out, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(key, out)
+	})
+}
+
+// RecordRetry increments Retries for seq, evicting the entry to the
+// dead-letter bucket once it exceeds maxRetries instead of resending
+// forever.
+func (o *Outbox) RecordRetry(seq int32) (deadLettered bool, err error) {
+	err = o.db.Update(func(tx *bbolt.Tx) error {
+		pending := tx.Bucket(bucketPending)
+		key := seqKey(seq)
+		buf := pending.Get(key)
+		if buf == nil {
+			return ErrNotFound
+		}
+
+		var e Entry
+		if err := json.Unmarshal(buf, &e); err != nil {
+			return err
+		}
+		e.Retries++
+
+		out, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+
+		if e.Retries > o.maxRetries {
+			deadLettered = true
+			if err := tx.Bucket(bucketDeadLetter).Put(key, out); err != nil {
+				return err
+			}
+			return pending.Delete(key)
+		}
+
+		return pending.Put(key, out)
+	})
+	return deadLettered, err
+}
+
+// Delete removes the pending entry at seq outright, e.g. once it has been
+// resubmitted under a new sequence number by Session.replayOutbox and no
+// longer needs tracking under its old one.
+func (o *Outbox) Delete(seq int32) error {
+	return o.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucketPending).Delete(seqKey(seq))
+	})
+}
+
+// Replay returns every entry still pending (i.e. not yet delivered), so a
+// restarting Session can requeue them through resendStream.
+func (o *Outbox) Replay() ([]Entry, error) {
+	var entries []Entry
+	err := o.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucketPending).ForEach(func(_, v []byte) error {
+			var e Entry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			entries = append(entries, e)
+			return nil
+		})
+	})
+	return entries, err
+}
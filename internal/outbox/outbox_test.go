@@ -0,0 +1,114 @@
+package outbox
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestOutbox(t *testing.T, maxRetries int) *Outbox {
+	t.Helper()
+	ob, err := Open(filepath.Join(t.TempDir(), "outbox.db"), maxRetries)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { ob.Close() })
+	return ob
+}
+
+func TestPutAndReplay(t *testing.T) {
+	ob := openTestOutbox(t, 3)
+
+	entry := Entry{Sequence: 1, Sender: "2001", Number: "2002", Text: "hi", Gateway: "zain", SubmitTime: time.Now()}
+	if err := ob.Put(entry); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	entries, err := ob.Replay()
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Sender != "2001" {
+		t.Fatalf("Replay = %+v, want one entry for sender 2001", entries)
+	}
+}
+
+func TestUpdateMessageIDAndMarkDelivered(t *testing.T) {
+	ob := openTestOutbox(t, 3)
+
+	if err := ob.Put(Entry{Sequence: 1, Sender: "2001"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := ob.UpdateMessageID(1, "smsc-msg-1"); err != nil {
+		t.Fatalf("UpdateMessageID: %v", err)
+	}
+
+	if err := ob.MarkDelivered("smsc-msg-1"); err != nil {
+		t.Fatalf("MarkDelivered: %v", err)
+	}
+
+	entries, err := ob.Replay()
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("Replay after MarkDelivered = %+v, want empty", entries)
+	}
+
+	if err := ob.MarkDelivered("does-not-exist"); err != ErrNotFound {
+		t.Fatalf("MarkDelivered(unknown) = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRecordRetryDeadLetters(t *testing.T) {
+	ob := openTestOutbox(t, 2)
+
+	if err := ob.Put(Entry{Sequence: 1, Sender: "2001"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		deadLettered, err := ob.RecordRetry(1)
+		if err != nil {
+			t.Fatalf("RecordRetry: %v", err)
+		}
+		if deadLettered {
+			t.Fatalf("RecordRetry dead-lettered after only %d retries, want after 3", i+1)
+		}
+	}
+
+	deadLettered, err := ob.RecordRetry(1)
+	if err != nil {
+		t.Fatalf("RecordRetry: %v", err)
+	}
+	if !deadLettered {
+		t.Fatal("RecordRetry did not dead-letter after exceeding maxRetries")
+	}
+
+	entries, err := ob.Replay()
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("Replay after dead-letter = %+v, want empty pending bucket", entries)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	ob := openTestOutbox(t, 3)
+
+	if err := ob.Put(Entry{Sequence: 1, Sender: "2001"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := ob.Delete(1); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	entries, err := ob.Replay()
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("Replay after Delete = %+v, want empty", entries)
+	}
+}
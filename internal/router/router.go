@@ -0,0 +1,200 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/linxGnu/gosmpp/pdu"
+	"github.com/rixtrayker/demo-smpp/internal/metrics"
+	"github.com/rixtrayker/demo-smpp/internal/session"
+)
+
+// MNPLookup resolves an MSISDN to the gateway (carrier) currently serving
+// it via a live mobile number portability query, so ported traffic reaches
+// whichever carrier actually owns the number today.
+type MNPLookup interface {
+	Lookup(ctx context.Context, msisdn string) (gateway string, err error)
+}
+
+// SubmitFunc submits through a specific gateway's Session and returns the
+// SubmitSMResp so Route can inspect its command status for fallback.
+type SubmitFunc func(ctx context.Context, s *session.Session, gateway string) (*pdu.SubmitSMResp, error)
+
+// retryableStatuses are SubmitSMResp command statuses that should trigger
+// failover to the next gateway rather than being surfaced as a hard error.
+var retryableStatuses = map[pdu.CommandStatus]bool{
+	pdu.ESME_RTHROTTLED: true,
+	pdu.ESME_RSYSERR:    true,
+}
+
+// Router owns the pool of Sessions keyed by gateway (replacing the
+// hard-coded portGateways list) and decides which one should carry ported
+// traffic for a given MSISDN, falling back across the rest of the pool on
+// SMSC-side throttling or system errors.
+type Router struct {
+	mu       sync.RWMutex
+	sessions map[string]*session.Session
+	strategy Strategy
+	mnp      MNPLookup
+}
+
+// NewRouter builds a Router with no sessions registered; call Register as
+// each gateway's Session comes up.
+func NewRouter(strategy Strategy, mnp MNPLookup) *Router {
+	return &Router{
+		sessions: make(map[string]*session.Session),
+		strategy: strategy,
+		mnp:      mnp,
+	}
+}
+
+// Register adds or replaces the Session serving the given gateway.
+func (r *Router) Register(gateway string, s *session.Session) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions[gateway] = s
+}
+
+// Unregister removes a gateway's Session, e.g. once Session.Stop returns.
+func (r *Router) Unregister(gateway string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sessions, gateway)
+}
+
+func (r *Router) pool() map[string]*session.Session {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	pool := make(map[string]*session.Session, len(r.sessions))
+	for gw, s := range r.sessions {
+		pool[gw] = s
+	}
+	return pool
+}
+
+// Route resolves msisdn to a preferred gateway via MNP, submits through it,
+// and falls back across the rest of the pool on a retryable SubmitSMResp,
+// backing off between attempts the same way Session.Start does.
+// StrategyFailfast gateways are never retried past the first attempt.
+func (r *Router) Route(ctx context.Context, msisdn, sender string, submit SubmitFunc) (*pdu.SubmitSMResp, error) {
+	pool := r.pool()
+	if len(pool) == 0 {
+		return nil, errors.New("router: no active sessions registered")
+	}
+
+	order, err := r.gatewayOrder(ctx, pool, msisdn, sender)
+	if err != nil {
+		return nil, err
+	}
+
+	const (
+		initialDelay = 100 * time.Millisecond
+		maxDelay     = 5 * time.Second
+		factor       = 2.0
+	)
+
+	var lastErr error
+	for attempt, gateway := range order {
+		s := pool[gateway]
+
+		resp, err := submit(ctx, s, gateway)
+		switch {
+		case err == nil && !retryableStatuses[resp.CommandStatus]:
+			metrics.RouterDecisions.WithLabelValues(gateway, "success").Inc()
+			return resp, nil
+		case err == nil:
+			lastErr = fmt.Errorf("gateway %s rejected submit: %s", gateway, resp.CommandStatus)
+		default:
+			lastErr = err
+		}
+		metrics.RouterFailovers.WithLabelValues(gateway).Inc()
+
+		if _, failfast := r.strategy.(*failfastStrategy); failfast {
+			break
+		}
+		if attempt == len(order)-1 {
+			break
+		}
+
+		delay := session.CalculateBackoff(initialDelay, maxDelay, factor, attempt)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+
+	return nil, fmt.Errorf("router: exhausted all gateways: %w", lastErr)
+}
+
+// gatewayOrder ranks the pool's gateways for this MSISDN/sender: the MNP
+// result (if any) goes first, then whatever the Strategy would have
+// picked, then the rest of the pool as a last-resort fallback.
+func (r *Router) gatewayOrder(ctx context.Context, pool map[string]*session.Session, msisdn, sender string) ([]string, error) {
+	ordered := make([]string, 0, len(pool))
+	seen := make(map[string]bool)
+
+	if r.mnp != nil {
+		if gw, err := r.mnp.Lookup(ctx, msisdn); err == nil {
+			if _, ok := pool[gw]; ok {
+				ordered = append(ordered, gw)
+				seen[gw] = true
+			}
+		}
+	}
+
+	if picked, err := r.strategy.Select(pool, sender); err == nil {
+		for gw, s := range pool {
+			if s == picked && !seen[gw] {
+				ordered = append(ordered, gw)
+				seen[gw] = true
+				break
+			}
+		}
+	}
+
+	for gw := range pool {
+		if !seen[gw] {
+			ordered = append(ordered, gw)
+			seen[gw] = true
+		}
+	}
+
+	if len(ordered) == 0 {
+		return nil, errors.New("router: no gateway candidates")
+	}
+	return ordered, nil
+}
+
+// SubmitViaSession adapts session.Session.SubmitAndAwait into a SubmitFunc,
+// the form Route expects, so callers don't have to write this glue
+// themselves for the common case of routing a single sender/number/text.
+func SubmitViaSession(sender, number, text string) SubmitFunc {
+	return func(ctx context.Context, s *session.Session, gateway string) (*pdu.SubmitSMResp, error) {
+		return s.SubmitAndAwait(ctx, sender, number, text)
+	}
+}
+
+// ConsumePortedStream drains a session's PortedStream and re-routes each
+// message through Route, so a number that's been ported away from src's
+// gateway is actually carried by whichever carrier r's Strategy/MNP lookup
+// says owns it now, instead of being read into a void. Runs until ctx is
+// cancelled or src's PortedStream is closed.
+func (r *Router) ConsumePortedStream(ctx context.Context, src *session.Session, sender string) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-src.PortedStream():
+			if !ok {
+				return
+			}
+			if _, err := r.Route(ctx, msg.Number, sender, SubmitViaSession(sender, msg.Number, msg.Text)); err != nil {
+				metrics.RouterFailovers.WithLabelValues(src.Gateway()).Inc()
+			}
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/linxGnu/gosmpp/pdu"
+	"github.com/rixtrayker/demo-smpp/internal/session"
+)
+
+// stubSubmit returns a SubmitFunc driven by per-gateway canned responses/
+// errors, recording the gateways it was actually called for in calls so
+// tests can assert both the outcome and the failover order Route took.
+func stubSubmit(responses map[string]*pdu.SubmitSMResp, errs map[string]error, calls *[]string) SubmitFunc {
+	return func(_ context.Context, _ *session.Session, gateway string) (*pdu.SubmitSMResp, error) {
+		*calls = append(*calls, gateway)
+		if err, ok := errs[gateway]; ok {
+			return nil, err
+		}
+		return responses[gateway], nil
+	}
+}
+
+type stubMNP struct {
+	gateway string
+	err     error
+}
+
+func (m stubMNP) Lookup(_ context.Context, _ string) (string, error) {
+	return m.gateway, m.err
+}
+
+func routerPool(gateways ...string) map[string]*session.Session {
+	pool := make(map[string]*session.Session, len(gateways))
+	for _, gw := range gateways {
+		pool[gw] = &session.Session{}
+	}
+	return pool
+}
+
+func newTestRouter(t *testing.T, strategyName StrategyName, priority []string, mnp MNPLookup, gateways ...string) *Router {
+	t.Helper()
+	strategy, err := NewStrategy(strategyName, priority)
+	if err != nil {
+		t.Fatalf("NewStrategy: %v", err)
+	}
+	r := NewRouter(strategy, mnp)
+	pool := routerPool(gateways...)
+	for gw, s := range pool {
+		r.Register(gw, s)
+	}
+	return r
+}
+
+func TestRouteSucceedsOnFirstGateway(t *testing.T) {
+	r := newTestRouter(t, StrategyFailover, []string{"zain", "mobily"}, nil, "zain", "mobily")
+
+	var calls []string
+	submit := stubSubmit(map[string]*pdu.SubmitSMResp{
+		"zain": {},
+	}, nil, &calls)
+
+	resp, err := r.Route(context.Background(), "2001", "sender", submit)
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if resp == nil {
+		t.Fatal("Route returned a nil response on success")
+	}
+	if len(calls) != 1 || calls[0] != "zain" {
+		t.Fatalf("calls = %v, want exactly one call to zain", calls)
+	}
+}
+
+func TestRouteFailsOverOnRetryableStatus(t *testing.T) {
+	r := newTestRouter(t, StrategyFailover, []string{"zain", "mobily"}, nil, "zain", "mobily")
+
+	var calls []string
+	submit := stubSubmit(map[string]*pdu.SubmitSMResp{
+		"zain":   {CommandStatus: pdu.ESME_RTHROTTLED},
+		"mobily": {},
+	}, nil, &calls)
+
+	resp, err := r.Route(context.Background(), "2001", "sender", submit)
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if resp.CommandStatus != pdu.ESME_ROK {
+		t.Fatalf("Route returned status %v, want the successful mobily response", resp.CommandStatus)
+	}
+	if len(calls) != 2 || calls[0] != "zain" || calls[1] != "mobily" {
+		t.Fatalf("calls = %v, want [zain mobily] in that order", calls)
+	}
+}
+
+func TestRouteFailfastStopsAfterFirstAttempt(t *testing.T) {
+	r := newTestRouter(t, StrategyFailfast, []string{"zain", "mobily"}, nil, "zain", "mobily")
+
+	var calls []string
+	submit := stubSubmit(map[string]*pdu.SubmitSMResp{
+		"zain":   {CommandStatus: pdu.ESME_RTHROTTLED},
+		"mobily": {},
+	}, nil, &calls)
+
+	if _, err := r.Route(context.Background(), "2001", "sender", submit); err == nil {
+		t.Fatal("Route with a failfast strategy did not error after a retryable first attempt")
+	}
+	if len(calls) != 1 || calls[0] != "zain" {
+		t.Fatalf("calls = %v, want exactly one call to zain (failfast must not retry)", calls)
+	}
+}
+
+func TestRouteExhaustsAllGatewaysReturnsError(t *testing.T) {
+	r := newTestRouter(t, StrategyFailover, []string{"zain", "mobily"}, nil, "zain", "mobily")
+
+	var calls []string
+	submit := stubSubmit(map[string]*pdu.SubmitSMResp{
+		"zain":   {CommandStatus: pdu.ESME_RTHROTTLED},
+		"mobily": {CommandStatus: pdu.ESME_RSYSERR},
+	}, nil, &calls)
+
+	if _, err := r.Route(context.Background(), "2001", "sender", submit); err == nil {
+		t.Fatal("Route did not error after every gateway returned a retryable status")
+	}
+	if len(calls) != 2 {
+		t.Fatalf("calls = %v, want both gateways attempted", calls)
+	}
+}
+
+func TestRouteNonRetryableErrorStillFailsOver(t *testing.T) {
+	r := newTestRouter(t, StrategyFailover, []string{"zain", "mobily"}, nil, "zain", "mobily")
+
+	var calls []string
+	submit := stubSubmit(map[string]*pdu.SubmitSMResp{
+		"mobily": {},
+	}, map[string]error{
+		"zain": errors.New("submit to zain: no active transport"),
+	}, &calls)
+
+	resp, err := r.Route(context.Background(), "2001", "sender", submit)
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if resp == nil {
+		t.Fatal("Route returned a nil response on success")
+	}
+	if len(calls) != 2 || calls[0] != "zain" || calls[1] != "mobily" {
+		t.Fatalf("calls = %v, want [zain mobily] in that order", calls)
+	}
+}
+
+func TestRouteNoSessionsRegistered(t *testing.T) {
+	strategy, err := NewStrategy(StrategyFailover, nil)
+	if err != nil {
+		t.Fatalf("NewStrategy: %v", err)
+	}
+	r := NewRouter(strategy, nil)
+
+	if _, err := r.Route(context.Background(), "2001", "sender", stubSubmit(nil, nil, &[]string{})); err == nil {
+		t.Fatal("Route with no sessions registered did not error")
+	}
+}
+
+func TestGatewayOrderPrefersMNPResultFirst(t *testing.T) {
+	r := newTestRouter(t, StrategyRoundRobin, nil, stubMNP{gateway: "stc"}, "zain", "mobily", "stc")
+
+	order, err := r.gatewayOrder(context.Background(), r.pool(), "2001", "sender")
+	if err != nil {
+		t.Fatalf("gatewayOrder: %v", err)
+	}
+	if len(order) == 0 || order[0] != "stc" {
+		t.Fatalf("gatewayOrder = %v, want stc (the MNP result) first", order)
+	}
+}
+
+func TestGatewayOrderFallsBackToStrategyWhenMNPMisses(t *testing.T) {
+	r := newTestRouter(t, StrategyFailover, []string{"mobily"}, stubMNP{err: errors.New("mnp lookup failed")}, "zain", "mobily")
+
+	order, err := r.gatewayOrder(context.Background(), r.pool(), "2001", "sender")
+	if err != nil {
+		t.Fatalf("gatewayOrder: %v", err)
+	}
+	if len(order) == 0 || order[0] != "mobily" {
+		t.Fatalf("gatewayOrder = %v, want mobily (the strategy's pick) first when MNP misses", order)
+	}
+}
+
+// ConsumePortedStream itself isn't covered here: exercising it needs a
+// Session with a real, initialized PortedStream, and that channel is built
+// from unexported state NewSession sets up internally (not reachable from
+// this package), so Route/gatewayOrder above — the failover/backoff/MNP
+// logic ConsumePortedStream's every iteration ultimately calls into — is
+// where the actual retry/ordering behavior is tested.
@@ -0,0 +1,187 @@
+package router
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rixtrayker/demo-smpp/internal/session"
+)
+
+// Strategy ranks the pool's gateways for a given sender, returning the one
+// that should be tried first. Router falls back to the rest of the pool if
+// the chosen gateway's SubmitSMResp comes back retryable.
+type Strategy interface {
+	Select(pool map[string]*session.Session, sender string) (*session.Session, error)
+}
+
+// StrategyName identifies a built-in Strategy, e.g. from config.
+type StrategyName string
+
+const (
+	StrategyFailover                 StrategyName = "failover"
+	StrategyFailfast                 StrategyName = "failfast"
+	StrategyRandom                   StrategyName = "random"
+	StrategyRoundRobin               StrategyName = "round_robin"
+	StrategyWeightedLeastOutstanding StrategyName = "weighted_least_outstanding"
+	StrategyStickyBySender           StrategyName = "sticky_by_sender"
+)
+
+// NewStrategy builds the named Strategy. priority only matters for
+// StrategyFailover/StrategyFailfast, where it's the gateway order to try.
+func NewStrategy(name StrategyName, priority []string) (Strategy, error) {
+	switch name {
+	case StrategyFailover:
+		return &failoverStrategy{priority: priority}, nil
+	case StrategyFailfast:
+		return &failfastStrategy{priority: priority}, nil
+	case StrategyRandom:
+		return &randomStrategy{}, nil
+	case StrategyRoundRobin:
+		return &roundRobinStrategy{}, nil
+	case StrategyWeightedLeastOutstanding:
+		return &weightedLeastOutstandingStrategy{}, nil
+	case StrategyStickyBySender:
+		return &stickyBySenderStrategy{sticky: make(map[string]string)}, nil
+	default:
+		return nil, fmt.Errorf("unknown routing strategy %q", name)
+	}
+}
+
+func noSessionsErr() error {
+	return fmt.Errorf("router: no active sessions available to route to")
+}
+
+func sortedGateways(pool map[string]*session.Session) []string {
+	gateways := make([]string, 0, len(pool))
+	for gw := range pool {
+		gateways = append(gateways, gw)
+	}
+	sort.Strings(gateways)
+	return gateways
+}
+
+// failoverStrategy always prefers the first reachable gateway in priority
+// order, accepting Router's usual fallback through the rest of the pool.
+type failoverStrategy struct {
+	priority []string
+}
+
+func (f *failoverStrategy) Select(pool map[string]*session.Session, _ string) (*session.Session, error) {
+	for _, gw := range f.priority {
+		if s, ok := pool[gw]; ok {
+			return s, nil
+		}
+	}
+	gateways := sortedGateways(pool)
+	if len(gateways) == 0 {
+		return nil, noSessionsErr()
+	}
+	return pool[gateways[0]], nil
+}
+
+// failfastStrategy is like failoverStrategy but signals Router should not
+// retry past the first pick — used for traffic where a stale delivery is
+// worse than a dropped one.
+type failfastStrategy struct {
+	priority []string
+}
+
+func (f *failfastStrategy) Select(pool map[string]*session.Session, sender string) (*session.Session, error) {
+	return (&failoverStrategy{priority: f.priority}).Select(pool, sender)
+}
+
+type randomStrategy struct{}
+
+func (r *randomStrategy) Select(pool map[string]*session.Session, _ string) (*session.Session, error) {
+	gateways := sortedGateways(pool)
+	if len(gateways) == 0 {
+		return nil, noSessionsErr()
+	}
+	return pool[gateways[rand.Intn(len(gateways))]], nil
+}
+
+type roundRobinStrategy struct {
+	next uint64
+}
+
+func (r *roundRobinStrategy) Select(pool map[string]*session.Session, _ string) (*session.Session, error) {
+	gateways := sortedGateways(pool)
+	if len(gateways) == 0 {
+		return nil, noSessionsErr()
+	}
+	idx := atomic.AddUint64(&r.next, 1)
+	return pool[gateways[int(idx)%len(gateways)]], nil
+}
+
+// weightedLeastOutstandingStrategy sends to whichever gateway is least
+// loaded *relative to its own maxOutstanding*, so a carrier nearing its
+// contracted limit gets less new traffic rather than queuing behind it, even
+// when another gateway in the pool has a much higher (or lower) cap and
+// would win on raw outstanding count alone.
+type weightedLeastOutstandingStrategy struct{}
+
+func (w *weightedLeastOutstandingStrategy) Select(pool map[string]*session.Session, _ string) (*session.Session, error) {
+	gateways := sortedGateways(pool)
+	if len(gateways) == 0 {
+		return nil, noSessionsErr()
+	}
+
+	best := gateways[0]
+	bestLoad := loadFraction(pool[best])
+	for _, gw := range gateways[1:] {
+		if load := loadFraction(pool[gw]); load < bestLoad {
+			best = gw
+			bestLoad = load
+		}
+	}
+	return pool[best], nil
+}
+
+// loadFraction is a gateway's Outstanding as a fraction of its own
+// MaxOutstanding, so gateways with different contracted caps are compared on
+// how loaded each actually is rather than on raw submit counts. A gateway
+// with no configured cap (MaxOutstanding <= 0, i.e. uncapped or untracked)
+// is treated as unloaded.
+func loadFraction(s *session.Session) float64 {
+	max := s.MaxOutstanding()
+	if max <= 0 {
+		return 0
+	}
+	return float64(s.Outstanding()) / float64(max)
+}
+
+// stickyBySenderStrategy pins every sender to the first gateway it was
+// routed to, so segments of the same concatenated message stay on one bind.
+type stickyBySenderStrategy struct {
+	mu     sync.Mutex
+	sticky map[string]string
+}
+
+func (s *stickyBySenderStrategy) Select(pool map[string]*session.Session, sender string) (*session.Session, error) {
+	gateways := sortedGateways(pool)
+	if len(gateways) == 0 {
+		return nil, noSessionsErr()
+	}
+
+	s.mu.Lock()
+	gw, ok := s.sticky[sender]
+	s.mu.Unlock()
+	if ok {
+		if sess, ok := pool[gw]; ok {
+			return sess, nil
+		}
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(sender))
+	chosen := gateways[int(hash.Sum32())%len(gateways)]
+
+	s.mu.Lock()
+	s.sticky[sender] = chosen
+	s.mu.Unlock()
+	return pool[chosen], nil
+}
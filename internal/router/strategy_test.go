@@ -0,0 +1,139 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/rixtrayker/demo-smpp/internal/session"
+)
+
+func testPool(gateways ...string) map[string]*session.Session {
+	pool := make(map[string]*session.Session, len(gateways))
+	for _, gw := range gateways {
+		pool[gw] = &session.Session{}
+	}
+	return pool
+}
+
+func TestNewStrategyUnknown(t *testing.T) {
+	if _, err := NewStrategy("not-a-real-strategy", nil); err == nil {
+		t.Fatal("NewStrategy with an unknown name did not error")
+	}
+}
+
+func TestFailoverStrategyPrefersPriorityOrder(t *testing.T) {
+	s, err := NewStrategy(StrategyFailover, []string{"mobily", "zain"})
+	if err != nil {
+		t.Fatalf("NewStrategy: %v", err)
+	}
+
+	pool := testPool("zain", "mobily", "stc")
+	picked, err := s.Select(pool, "2001")
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if picked != pool["mobily"] {
+		t.Fatal("failover strategy did not prefer the first priority gateway present in the pool")
+	}
+}
+
+func TestFailoverStrategyNoSessions(t *testing.T) {
+	s, err := NewStrategy(StrategyFailover, nil)
+	if err != nil {
+		t.Fatalf("NewStrategy: %v", err)
+	}
+	if _, err := s.Select(map[string]*session.Session{}, "2001"); err == nil {
+		t.Fatal("Select on an empty pool did not error")
+	}
+}
+
+func TestStickyBySenderStrategyIsStable(t *testing.T) {
+	s, err := NewStrategy(StrategyStickyBySender, nil)
+	if err != nil {
+		t.Fatalf("NewStrategy: %v", err)
+	}
+
+	pool := testPool("zain", "mobily", "stc")
+	first, err := s.Select(pool, "2001")
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		again, err := s.Select(pool, "2001")
+		if err != nil {
+			t.Fatalf("Select: %v", err)
+		}
+		if again != first {
+			t.Fatal("sticky_by_sender strategy picked a different gateway for the same sender")
+		}
+	}
+}
+
+func TestWeightedLeastOutstandingPrefersLowerRelativeLoad(t *testing.T) {
+	s, err := NewStrategy(StrategyWeightedLeastOutstanding, nil)
+	if err != nil {
+		t.Fatalf("NewStrategy: %v", err)
+	}
+
+	// zain is capped at 5 and sitting at 3/5 (60% loaded); mobily is capped
+	// at 1000 and sitting at 5/1000 (0.5% loaded). Raw outstanding count
+	// alone would pick zain (3 < 5); weighted by each gateway's own cap,
+	// mobily is the far less loaded choice.
+	zain := &session.Session{}
+	session.WithMaxOutstanding(5)(zain)
+	zain.SetOutstanding(3)
+
+	mobily := &session.Session{}
+	session.WithMaxOutstanding(1000)(mobily)
+	mobily.SetOutstanding(5)
+
+	pool := map[string]*session.Session{"zain": zain, "mobily": mobily}
+	picked, err := s.Select(pool, "2001")
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if picked != mobily {
+		t.Fatal("weighted_least_outstanding picked the gateway with more raw outstanding submits relative to its own cap")
+	}
+}
+
+func TestWeightedLeastOutstandingTreatsUncappedAsUnloaded(t *testing.T) {
+	s, err := NewStrategy(StrategyWeightedLeastOutstanding, nil)
+	if err != nil {
+		t.Fatalf("NewStrategy: %v", err)
+	}
+
+	capped := &session.Session{}
+	session.WithMaxOutstanding(5)(capped)
+	capped.SetOutstanding(1)
+
+	uncapped := &session.Session{} // MaxOutstanding() == 0: not tracked
+
+	pool := map[string]*session.Session{"capped": capped, "uncapped": uncapped}
+	picked, err := s.Select(pool, "2001")
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if picked != uncapped {
+		t.Fatal("weighted_least_outstanding did not treat an uncapped gateway (MaxOutstanding() == 0) as unloaded")
+	}
+}
+
+func TestRoundRobinStrategyCyclesThroughGateways(t *testing.T) {
+	s, err := NewStrategy(StrategyRoundRobin, nil)
+	if err != nil {
+		t.Fatalf("NewStrategy: %v", err)
+	}
+
+	pool := testPool("zain", "mobily")
+	seen := make(map[*session.Session]bool)
+	for i := 0; i < 4; i++ {
+		picked, err := s.Select(pool, "2001")
+		if err != nil {
+			t.Fatalf("Select: %v", err)
+		}
+		seen[picked] = true
+	}
+	if len(seen) != len(pool) {
+		t.Fatalf("round_robin only ever picked %d of %d gateways", len(seen), len(pool))
+	}
+}
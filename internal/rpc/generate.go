@@ -0,0 +1,18 @@
+package rpc
+
+// smpp.proto documents this package's request/response shapes for anyone
+// wiring up a gRPC client against a future server, but nothing in this
+// package is generated from it: this tree has never had protoc available
+// to run the grpc/grpc-gateway codegen the comments below describe. Server
+// and NewHTTPHandler are hand-written Go types/handlers that match the
+// proto's field names, served over plain net/http+JSON instead.
+//
+// Once protoc is available, regenerating real gRPC stubs from smpp.proto
+// and wiring Server to implement them is a drop-in addition alongside the
+// REST gateway, not a replacement for it.
+//
+//go:generate protoc -I . \
+//go:generate   --go_out=. --go_opt=paths=source_relative \
+//go:generate   --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//go:generate   --grpc-gateway_out=. --grpc-gateway_opt=paths=source_relative \
+//go:generate   smpp.proto
@@ -0,0 +1,138 @@
+package rpc
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// NewHTTPHandler builds the REST gateway smpp.proto's service comment
+// promises, routing plain JSON/HTTP requests to srv without requiring
+// callers to speak gRPC.
+func NewHTTPHandler(srv *Server) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/submit", srv.handleSubmitSM)
+	mux.HandleFunc("/v1/query", srv.handleQuerySM)
+	mux.HandleFunc("/v1/cancel", srv.handleCancelSM)
+	mux.HandleFunc("/v1/replace", srv.handleReplaceSM)
+	mux.HandleFunc("/v1/delivery-receipts", srv.handleDeliveryReceipts)
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func (srv *Server) handleSubmitSM(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req SubmitSMRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := srv.SubmitSM(r.Context(), req)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (srv *Server) handleQuerySM(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req QuerySMRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := srv.QuerySM(r.Context(), req)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (srv *Server) handleCancelSM(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req CancelSMRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := srv.CancelSM(r.Context(), req)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (srv *Server) handleReplaceSM(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ReplaceSMRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := srv.ReplaceSM(r.Context(), req)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleDeliveryReceipts streams newline-delimited JSON DeliveryReceipt
+// objects for as long as the client keeps the connection open, flushing
+// after every receipt so it arrives promptly rather than batched.
+func (srv *Server) handleDeliveryReceipts(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	gateway := r.URL.Query().Get("gateway")
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	err := srv.DeliveryReceipts(r.Context(), gateway, func(receipt DeliveryReceipt) error {
+		if err := enc.Encode(receipt); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	})
+	if err != nil && r.Context().Err() == nil {
+		// Only the client-driven cancellation path is expected; anything
+		// else means the encode/flush itself failed mid-stream.
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
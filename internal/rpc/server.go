@@ -0,0 +1,291 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rixtrayker/demo-smpp/internal/metrics"
+	"github.com/rixtrayker/demo-smpp/internal/router"
+	"github.com/rixtrayker/demo-smpp/internal/session"
+)
+
+// SubmitSMRequest mirrors smpp.proto's SubmitSMRequest. Gateway optionally
+// pins the submit to a carrier; left empty, the configured routing
+// Strategy picks one.
+type SubmitSMRequest struct {
+	Sender  string `json:"sender"`
+	Number  string `json:"number"`
+	Text    string `json:"text"`
+	Gateway string `json:"gateway,omitempty"`
+}
+
+type SubmitSMResponse struct {
+	MessageID string `json:"message_id"`
+	Gateway   string `json:"gateway"`
+}
+
+type QuerySMRequest struct {
+	MessageID string `json:"message_id"`
+	Gateway   string `json:"gateway"`
+}
+
+type QuerySMResponse struct {
+	MessageID string `json:"message_id"`
+	Status    string `json:"status"`
+}
+
+type CancelSMRequest struct {
+	MessageID string `json:"message_id"`
+	Gateway   string `json:"gateway"`
+}
+
+type CancelSMResponse struct {
+	Cancelled bool `json:"cancelled"`
+}
+
+type ReplaceSMRequest struct {
+	MessageID string `json:"message_id"`
+	Gateway   string `json:"gateway"`
+	Text      string `json:"text"`
+}
+
+type ReplaceSMResponse struct {
+	Replaced bool `json:"replaced"`
+}
+
+// DeliveryReceipt mirrors smpp.proto's DeliveryReceipt, one per delivered
+// (or failed) SubmitSM a Session correlates from an inbound DeliverSM.
+type DeliveryReceipt struct {
+	MessageID string `json:"message_id"`
+	Gateway   string `json:"gateway"`
+	Status    string `json:"status"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// Server is the transport-agnostic front door for producers that previously
+// had no way to reach a Session other than the internal queue streams. It
+// picks a Session per request through a pluggable router.Strategy instead
+// of requiring producers to know about gateways at all, and is served over
+// plain net/http+JSON by NewHTTPHandler rather than gRPC: this tree has
+// never had protoc available to generate the pb package smpp.proto
+// describes, so a REST gateway that actually compiles is what ships today.
+type Server struct {
+	mu       sync.RWMutex
+	sessions map[string]*session.Session
+	strategy router.Strategy
+
+	receiptMu   sync.Mutex
+	receiptSubs map[chan DeliveryReceipt]string
+}
+
+func noSessionsErr() error {
+	return fmt.Errorf("rpc: no active sessions available to route to")
+}
+
+// NewServer builds a Server with no sessions registered; call Register as
+// each gateway's Session comes up. Pass ReceiptHook to session.NewSession
+// via session.WithDeliveryReceiptHook so delivery receipts reach
+// DeliveryReceipts subscribers.
+func NewServer(strategy router.Strategy) *Server {
+	return &Server{
+		sessions:    make(map[string]*session.Session),
+		strategy:    strategy,
+		receiptSubs: make(map[chan DeliveryReceipt]string),
+	}
+}
+
+// Register adds or replaces the Session serving the given gateway.
+func (srv *Server) Register(gateway string, s *session.Session) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	srv.sessions[gateway] = s
+}
+
+// Unregister removes a gateway's Session, e.g. once Session.Stop returns.
+func (srv *Server) Unregister(gateway string) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	delete(srv.sessions, gateway)
+}
+
+func (srv *Server) pool() map[string]*session.Session {
+	srv.mu.RLock()
+	defer srv.mu.RUnlock()
+	pool := make(map[string]*session.Session, len(srv.sessions))
+	for gw, s := range srv.sessions {
+		pool[gw] = s
+	}
+	return pool
+}
+
+// selectSession resolves the request's pinned gateway if one was given,
+// otherwise defers to the configured Strategy, and records the routing
+// decision for observability.
+func (srv *Server) selectSession(gateway, sender string) (string, *session.Session, error) {
+	pool := srv.pool()
+
+	if gateway != "" {
+		s, ok := pool[gateway]
+		if !ok {
+			return "", nil, noSessionsErr()
+		}
+		metrics.RPCRoutingDecisions.WithLabelValues(gateway, "pinned").Inc()
+		return gateway, s, nil
+	}
+
+	s, err := srv.strategy.Select(pool, sender)
+	if err != nil {
+		return "", nil, err
+	}
+
+	resolved := ""
+	for gw, candidate := range pool {
+		if candidate == s {
+			resolved = gw
+			break
+		}
+	}
+	metrics.RPCRoutingDecisions.WithLabelValues(resolved, "strategy").Inc()
+	return resolved, s, nil
+}
+
+func observeLatency(method string, start time.Time) {
+	metrics.RPCLatency.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// SubmitSM resolves req's gateway (pinned or via Strategy) and submits
+// through it, blocking for the SMSC's SubmitSMResp.
+func (srv *Server) SubmitSM(ctx context.Context, req SubmitSMRequest) (*SubmitSMResponse, error) {
+	defer observeLatency("SubmitSM", time.Now())
+
+	gateway, s, err := srv.selectSession(req.Gateway, req.Sender)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.SubmitAndAwait(ctx, req.Sender, req.Number, req.Text)
+	if err != nil {
+		return nil, fmt.Errorf("rpc: submit via %s: %w", gateway, err)
+	}
+	return &SubmitSMResponse{MessageID: resp.MessageID, Gateway: gateway}, nil
+}
+
+// QuerySM reports the SMSC's current status for a previously submitted
+// message. The underlying SMPP QuerySM PDU also takes a source address,
+// which smpp.proto's QuerySMRequest doesn't carry; it's sent empty.
+func (srv *Server) QuerySM(ctx context.Context, req QuerySMRequest) (*QuerySMResponse, error) {
+	defer observeLatency("QuerySM", time.Now())
+
+	_, s, err := srv.selectSession(req.Gateway, "")
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.QuerySM(ctx, req.MessageID, "")
+	if err != nil {
+		return nil, err
+	}
+	return &QuerySMResponse{MessageID: req.MessageID, Status: resp.CommandStatus.String()}, nil
+}
+
+// CancelSM asks the SMSC to drop a previously submitted, undelivered
+// message.
+func (srv *Server) CancelSM(ctx context.Context, req CancelSMRequest) (*CancelSMResponse, error) {
+	defer observeLatency("CancelSM", time.Now())
+
+	_, s, err := srv.selectSession(req.Gateway, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.CancelSM(ctx, req.MessageID, "", ""); err != nil {
+		return nil, err
+	}
+	return &CancelSMResponse{Cancelled: true}, nil
+}
+
+// ReplaceSM asks the SMSC to swap the body of a previously submitted,
+// undelivered message for req.Text.
+func (srv *Server) ReplaceSM(ctx context.Context, req ReplaceSMRequest) (*ReplaceSMResponse, error) {
+	defer observeLatency("ReplaceSM", time.Now())
+
+	_, s, err := srv.selectSession(req.Gateway, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.ReplaceSM(ctx, req.MessageID, "", req.Text); err != nil {
+		return nil, err
+	}
+	return &ReplaceSMResponse{Replaced: true}, nil
+}
+
+// ReceiptHook returns the callback to pass to session.WithDeliveryReceiptHook
+// for every Session registered on this Server, so HandleDeliverSM's
+// correlated delivery receipts reach DeliveryReceipts subscribers.
+func (srv *Server) ReceiptHook() func(gateway, messageID, status string) {
+	return func(gateway, messageID, status string) {
+		receipt := DeliveryReceipt{
+			MessageID: messageID,
+			Gateway:   gateway,
+			Status:    status,
+			Timestamp: time.Now().Unix(),
+		}
+
+		srv.receiptMu.Lock()
+		defer srv.receiptMu.Unlock()
+		for ch, wantGateway := range srv.receiptSubs {
+			if wantGateway != "" && wantGateway != gateway {
+				continue
+			}
+			select {
+			case ch <- receipt:
+			default:
+				// Slow subscriber; drop rather than block every gateway's
+				// delivery path on one stalled stream.
+			}
+		}
+	}
+}
+
+// subscribeReceipts registers a channel to receive delivery receipts for
+// gateway (or every gateway, if empty), returning an unsubscribe func.
+func (srv *Server) subscribeReceipts(gateway string) (chan DeliveryReceipt, func()) {
+	ch := make(chan DeliveryReceipt, 16)
+
+	srv.receiptMu.Lock()
+	srv.receiptSubs[ch] = gateway
+	srv.receiptMu.Unlock()
+
+	return ch, func() {
+		srv.receiptMu.Lock()
+		delete(srv.receiptSubs, ch)
+		srv.receiptMu.Unlock()
+		close(ch)
+	}
+}
+
+// DeliveryReceipts streams delivery receipts for gateway (every active
+// gateway if empty) to send, until ctx is cancelled.
+func (srv *Server) DeliveryReceipts(ctx context.Context, gateway string, send func(DeliveryReceipt) error) error {
+	defer observeLatency("DeliveryReceipts", time.Now())
+
+	ch, unsubscribe := srv.subscribeReceipts(gateway)
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case receipt, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if err := send(receipt); err != nil {
+				return err
+			}
+		}
+	}
+}
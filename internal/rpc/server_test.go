@@ -0,0 +1,85 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/demo-smpp/internal/router"
+	"github.com/rixtrayker/demo-smpp/internal/session"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	strategy, err := router.NewStrategy(router.StrategyRoundRobin, nil)
+	if err != nil {
+		t.Fatalf("NewStrategy: %v", err)
+	}
+	return NewServer(strategy)
+}
+
+func TestSelectSessionPinnedGateway(t *testing.T) {
+	srv := newTestServer(t)
+	srv.Register("zain", &session.Session{})
+	srv.Register("mobily", &session.Session{})
+
+	gateway, _, err := srv.selectSession("mobily", "2001")
+	if err != nil {
+		t.Fatalf("selectSession: %v", err)
+	}
+	if gateway != "mobily" {
+		t.Fatalf("selectSession pinned gateway = %q, want mobily", gateway)
+	}
+}
+
+func TestSelectSessionUnknownPinnedGateway(t *testing.T) {
+	srv := newTestServer(t)
+	srv.Register("zain", &session.Session{})
+
+	if _, _, err := srv.selectSession("stc", "2001"); err == nil {
+		t.Fatal("selectSession with an unregistered pinned gateway did not error")
+	}
+}
+
+func TestSelectSessionNoSessionsRegistered(t *testing.T) {
+	srv := newTestServer(t)
+
+	if _, _, err := srv.selectSession("", "2001"); err == nil {
+		t.Fatal("selectSession with no sessions registered did not error")
+	}
+}
+
+func TestDeliveryReceiptsFiltersByGateway(t *testing.T) {
+	srv := newTestServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	received := make(chan DeliveryReceipt, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.DeliveryReceipts(ctx, "zain", func(r DeliveryReceipt) error {
+			received <- r
+			return nil
+		})
+	}()
+
+	// Give the subscriber a moment to register before publishing.
+	time.Sleep(10 * time.Millisecond)
+
+	hook := srv.ReceiptHook()
+	hook("mobily", "msg-1", "DELIVRD") // filtered out, different gateway
+	hook("zain", "msg-2", "DELIVRD")   // should reach the subscriber
+
+	select {
+	case r := <-received:
+		if r.Gateway != "zain" || r.MessageID != "msg-2" {
+			t.Fatalf("received %+v, want gateway=zain message_id=msg-2", r)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for filtered delivery receipt")
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,42 @@
+package session
+
+import "time"
+
+// CalculateBackoff exposes the same exponential-backoff-with-cap logic
+// Start uses to retry a failed bind, so other subsystems (e.g. the carrier
+// Router) that fail over between gateways back off the same way instead of
+// reimplementing it.
+func CalculateBackoff(initial, max time.Duration, factor float64, attempt int) time.Duration {
+	return calculateBackoff(initial, max, factor, attempt)
+}
+
+// Outstanding reports how many submits are currently reserved against
+// maxOutstanding, for strategies (e.g. WeightedLeastOutstanding) that want
+// to route away from a session nearing its limit.
+func (s *Session) Outstanding() int {
+	return len(s.outstandingCh)
+}
+
+// MaxOutstanding reports the cap Outstanding is reserved against, so
+// strategies can compare load as a fraction of each gateway's own contracted
+// limit instead of raw counts, which aren't comparable across gateways with
+// different caps.
+func (s *Session) MaxOutstanding() int {
+	return s.maxOutstanding
+}
+
+// Gateway returns the carrier name this session was configured for.
+func (s *Session) Gateway() string {
+	return s.gateway
+}
+
+// SetOutstanding fills n outstanding slots directly, for tests in other
+// packages (e.g. the router's weighting strategy) that need to simulate a
+// gateway's current load without a live transport to submit real PDUs
+// through.
+func (s *Session) SetOutstanding(n int) {
+	s.outstandingCh = make(chan struct{}, n)
+	for i := 0; i < n; i++ {
+		s.outstandingCh <- struct{}{}
+	}
+}
@@ -0,0 +1,186 @@
+package session
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/linxGnu/gosmpp/pdu"
+	"github.com/rixtrayker/demo-smpp/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// noFixedPingInterval is handed to gosmpp as EnquireLink when adaptive
+// keepalive is on, so its own fixed-schedule ping effectively never fires
+// and watchKeepalive's overdue-based ping is the only one in play.
+const noFixedPingInterval = 24 * time.Hour
+
+// WithEnquireLink overrides the fixed 5s EnquireLink interval gosmpp uses
+// when adaptive keepalive (WithKeepalive) isn't enabled.
+func WithEnquireLink(d time.Duration) Option {
+	return func(s *Session) {
+		s.enquireLink = d
+	}
+}
+
+// WithReadTimeout overrides the fixed 10s PDU read timeout.
+func WithReadTimeout(d time.Duration) Option {
+	return func(s *Session) {
+		s.readTimeout = d
+	}
+}
+
+// WithRebindingInterval overrides the fixed 600s rebind interval.
+func WithRebindingInterval(d time.Duration) Option {
+	return func(s *Session) {
+		s.rebindingInterval = d
+	}
+}
+
+// WithKeepalive switches the session from gosmpp's fixed-schedule
+// EnquireLink to an adaptive mode: a ping is only considered "due" once
+// `interval` has passed with no PDU activity of any kind, and `maxMissed`
+// consecutive overdue intervals force an immediate rebind rather than
+// waiting on gosmpp's own OnRebindingError detection. Carriers vary widely
+// in how aggressively they expect to be pinged, so this lets sensitivity
+// be tuned per gateway.
+func WithKeepalive(interval time.Duration, maxMissed int) Option {
+	return func(s *Session) {
+		s.keepaliveInterval = interval
+		s.keepaliveMaxMissed = maxMissed
+		s.adaptiveKeepalive = true
+	}
+}
+
+// effectiveEnquireLink is what getSettings hands gosmpp as Settings.EnquireLink.
+// With adaptive keepalive on, gosmpp's own fixed-schedule ping is pushed out
+// far enough to never fire, so watchKeepalive's overdue-based ping is the
+// only EnquireLink actually sent; otherwise it's the configured fixed interval.
+func (s *Session) effectiveEnquireLink() time.Duration {
+	if s.adaptiveKeepalive {
+		return noFixedPingInterval
+	}
+	return s.enquireLink
+}
+
+// touchLastRxPDU records that a PDU of any kind was just received, used by
+// the adaptive keepalive loop to decide whether a ping is overdue, and
+// resets the missed-ping counter since the link just proved itself alive.
+func (s *Session) touchLastRxPDU() {
+	s.lastRxMu.Lock()
+	s.lastRxPDU = time.Now()
+	s.lastRxMu.Unlock()
+	atomic.StoreInt32(&s.keepaliveMissed, 0)
+}
+
+func (s *Session) secondsSinceLastRxPDU() time.Duration {
+	s.lastRxMu.Lock()
+	last := s.lastRxPDU
+	s.lastRxMu.Unlock()
+	if last.IsZero() {
+		return 0
+	}
+	return time.Since(last)
+}
+
+// pingDue reports whether a full keepaliveInterval has passed since
+// watchKeepalive last actually pinged the SMSC, true if it never has. This
+// is what keeps the overdue branch below firing once per interval instead
+// of once per watchKeepalive tick.
+func (s *Session) pingDue() bool {
+	s.lastPingMu.Lock()
+	last := s.lastPingSent
+	s.lastPingMu.Unlock()
+	return last.IsZero() || time.Since(last) >= s.keepaliveInterval
+}
+
+// touchLastPing records that watchKeepalive just sent (or attempted) a ping.
+func (s *Session) touchLastPing() {
+	s.lastPingMu.Lock()
+	s.lastPingSent = time.Now()
+	s.lastPingMu.Unlock()
+}
+
+// sendEnquireLink actually pings the SMSC, the real adaptive-ping
+// replacement for gosmpp's fixed schedule: called from watchKeepalive once
+// an interval has passed with no RX activity, instead of letting gosmpp
+// fire EnquireLink on a timer regardless of whether the link is idle.
+func (s *Session) sendEnquireLink() error {
+	transport := s.activeTransport()
+	if transport == nil {
+		return ErrNoActiveTransport
+	}
+	return transport.Submit(pdu.NewEnquireLink())
+}
+
+// watchKeepalive runs the adaptive-ping supervisory loop until the
+// session's context is cancelled. It's a no-op unless WithKeepalive was
+// given; without it, gosmpp's own fixed-schedule EnquireLink and
+// OnRebindingError handle keepalive as before.
+func (s *Session) watchKeepalive() {
+	if !s.adaptiveKeepalive {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				elapsed := s.secondsSinceLastRxPDU()
+				metrics.SecondsSinceLastRxPDU.WithLabelValues(s.gateway).Set(elapsed.Seconds())
+
+				if elapsed < s.keepaliveInterval {
+					continue
+				}
+
+				// Overdue stays true on every tick until touchLastRxPDU
+				// resets it, so without this we'd re-ping and count a
+				// missed interval every second instead of every
+				// keepaliveInterval.
+				if !s.pingDue() {
+					continue
+				}
+				s.touchLastPing()
+
+				if err := s.sendEnquireLink(); err != nil {
+					s.logger.Error("Adaptive keepalive ping failed to send",
+						s.sessionFields(zap.Error(err))...)
+				}
+
+				missed := atomic.AddInt32(&s.keepaliveMissed, 1)
+				s.logger.Info("Adaptive keepalive ping overdue",
+					s.sessionFields(zap.Duration("since_last_rx", elapsed), zap.Int32("missed", missed))...)
+
+				if int(missed) >= s.keepaliveMaxMissed {
+					s.logger.Error("Adaptive keepalive exceeded max missed pings, forcing rebind",
+						s.sessionFields(zap.Int32("missed", missed))...)
+					s.forceRebind()
+					atomic.StoreInt32(&s.keepaliveMissed, 0)
+				}
+			}
+		}
+	}()
+}
+
+// forceRebind tears down the live gosmpp sessions and reconnects, the same
+// recovery OnRebindingError triggers, for when adaptive keepalive detects a
+// dead link before gosmpp's own watchdog does.
+func (s *Session) forceRebind() {
+	if s.smppSessions.transceiver != nil {
+		s.smppSessions.transceiver.Close()
+	}
+	if s.smppSessions.receiver != nil {
+		s.smppSessions.receiver.Close()
+	}
+	if s.smppSessions.transmitter != nil {
+		s.smppSessions.transmitter.Close()
+	}
+
+	if err := s.connectSessions(); err != nil {
+		s.logger.Error("Forced rebind failed", s.sessionFields(zap.Error(err))...)
+	}
+}
@@ -0,0 +1,59 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEffectiveEnquireLinkFixedWhenNotAdaptive(t *testing.T) {
+	s := &Session{enquireLink: 7 * time.Second}
+	if got := s.effectiveEnquireLink(); got != 7*time.Second {
+		t.Errorf("effectiveEnquireLink() = %v, want the configured fixed interval", got)
+	}
+}
+
+func TestEffectiveEnquireLinkDisablesFixedPingWhenAdaptive(t *testing.T) {
+	s := &Session{enquireLink: 7 * time.Second, adaptiveKeepalive: true}
+	if got := s.effectiveEnquireLink(); got != noFixedPingInterval {
+		t.Errorf("effectiveEnquireLink() = %v, want noFixedPingInterval so gosmpp's fixed ping never fires", got)
+	}
+}
+
+func TestTouchLastRxPDUResetsMissedCounter(t *testing.T) {
+	s := &Session{keepaliveMissed: 3}
+	s.touchLastRxPDU()
+	if s.keepaliveMissed != 0 {
+		t.Errorf("keepaliveMissed = %d after touchLastRxPDU, want 0", s.keepaliveMissed)
+	}
+}
+
+func TestSecondsSinceLastRxPDUZeroBeforeFirstTouch(t *testing.T) {
+	s := &Session{}
+	if got := s.secondsSinceLastRxPDU(); got != 0 {
+		t.Errorf("secondsSinceLastRxPDU() before any touch = %v, want 0", got)
+	}
+}
+
+func TestPingDueBeforeFirstPing(t *testing.T) {
+	s := &Session{keepaliveInterval: 30 * time.Second}
+	if !s.pingDue() {
+		t.Error("pingDue() = false before any ping has ever been sent, want true")
+	}
+}
+
+func TestPingDueFalseImmediatelyAfterTouchLastPing(t *testing.T) {
+	s := &Session{keepaliveInterval: 30 * time.Second}
+	s.touchLastPing()
+	if s.pingDue() {
+		t.Error("pingDue() = true immediately after touchLastPing, want false")
+	}
+}
+
+func TestPingDueTrueOnceIntervalElapses(t *testing.T) {
+	s := &Session{keepaliveInterval: time.Millisecond}
+	s.touchLastPing()
+	time.Sleep(2 * time.Millisecond)
+	if !s.pingDue() {
+		t.Error("pingDue() = false once keepaliveInterval has elapsed since the last ping, want true")
+	}
+}
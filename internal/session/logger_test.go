@@ -0,0 +1,42 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/linxGnu/gosmpp/pdu"
+	"go.uber.org/zap"
+)
+
+func fieldNames(fields []zap.Field) map[string]bool {
+	names := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		names[f.Key] = true
+	}
+	return names
+}
+
+func TestSessionFieldsIncludesRequiredKeys(t *testing.T) {
+	s := &Session{gateway: "zain", sessionType: Transceiver}
+	names := fieldNames(s.sessionFields())
+
+	for _, want := range []string{"gateway", "session_type", "smsc", "system_id"} {
+		if !names[want] {
+			t.Errorf("sessionFields missing required key %q", want)
+		}
+	}
+	if names["remote_addr"] {
+		t.Error("sessionFields has a remote_addr key, but gosmpp's Session exposes no real peer address to put in it")
+	}
+}
+
+func TestPDUFieldsIncludesSequenceAndMessageID(t *testing.T) {
+	resp := &pdu.SubmitSMResp{MessageID: "abc-123"}
+	names := fieldNames(pduFields(resp))
+
+	if !names["sequence"] {
+		t.Error("pduFields missing sequence key")
+	}
+	if !names["message_id"] {
+		t.Error("pduFields missing message_id key for SubmitSMResp")
+	}
+}
@@ -0,0 +1,70 @@
+package session
+
+import (
+	"github.com/rixtrayker/demo-smpp/internal/outbox"
+	"go.uber.org/zap"
+)
+
+// WithOutbox backs the session's resend queue with a BoltDB-based WAL at
+// path, so in-flight submits and their delivery-receipt correlation
+// survive a crash or SMSC-side rebind instead of living only in
+// messagesStatus/resendStream. Retries beyond maxRetries are evicted to
+// the outbox's dead-letter bucket rather than resent forever.
+func WithOutbox(path string, maxRetries int) Option {
+	return func(s *Session) {
+		ob, err := outbox.Open(path, maxRetries)
+		if err != nil {
+			s.logger.Error("Failed to open outbox, falling back to in-memory resend only",
+				s.sessionFields(zap.Error(err), zap.String("path", path))...)
+			return
+		}
+		s.outbox = ob
+	}
+}
+
+// replayOutbox resubmits everything the outbox still had pending from
+// before a restart or crash, through the same SubmitSM path a fresh
+// request would use. Each entry gets its own goroutine so one slow/stuck
+// SMSC gateway doesn't hold up the rest of the replay; failures are
+// recorded against the entry's retry count exactly like a live submit
+// failure would be.
+func (s *Session) replayOutbox() {
+	if s.outbox == nil {
+		return
+	}
+
+	entries, err := s.outbox.Replay()
+	if err != nil {
+		s.logger.Error("Failed to replay outbox", s.sessionFields(zap.Error(err))...)
+		return
+	}
+	if len(entries) == 0 {
+		return
+	}
+
+	s.logger.Info("Replaying pending outbox entries after restart",
+		s.sessionFields(zap.Int("count", len(entries)))...)
+
+	for _, entry := range entries {
+		entry := entry
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+
+			if _, err := s.SubmitSM(s.ctx, entry.Sender, entry.Number, entry.Text); err != nil {
+				s.logger.Error("Failed to replay outbox entry",
+					s.sessionFields(zap.Error(err), zap.Int32("sequence", entry.Sequence))...)
+				s.handleSubmitRetry(entry.Sequence)
+				return
+			}
+
+			// SubmitSM above wrote a fresh entry under the new sequence
+			// number gosmpp assigned; drop the stale one so it isn't
+			// replayed again on the next restart.
+			if err := s.outbox.Delete(entry.Sequence); err != nil {
+				s.logger.Error("Failed to drop replayed outbox entry",
+					s.sessionFields(zap.Error(err), zap.Int32("sequence", entry.Sequence))...)
+			}
+		}()
+	}
+}
@@ -2,18 +2,21 @@ package session
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 
 	"github.com/linxGnu/gosmpp"
 	"github.com/linxGnu/gosmpp/pdu"
-	"github.com/phuslu/log"
 	"github.com/rixtrayker/demo-smpp/internal/config"
 	"github.com/rixtrayker/demo-smpp/internal/dtos"
 	"github.com/rixtrayker/demo-smpp/internal/metrics"
+	"github.com/rixtrayker/demo-smpp/internal/outbox"
 	"github.com/rixtrayker/demo-smpp/internal/queue"
 	"github.com/rixtrayker/demo-smpp/internal/response"
+	"go.uber.org/zap"
 	"golang.org/x/time/rate"
 )
 
@@ -27,7 +30,8 @@ const (
 
 type Session struct {
 	ctx 			 context.Context
-	logger 			 log.Logger
+	cancel           context.CancelFunc
+	logger 			 Logger
 	gateway           string
 	sessionType       SessionType
 	startTime         time.Time
@@ -51,13 +55,25 @@ type Session struct {
 	enquireLink       time.Duration
 	readTimeout       time.Duration
 	rebindingInterval time.Duration
-	portGateways      []string
 	smppSessions      SMPPSessions
     shutdown          CloseSignals
+	tlsCfg            *TLSConfig
+	tlsConfig         *tls.Config
+	outbox            *outbox.Outbox
+	adaptiveKeepalive  bool
+	keepaliveInterval  time.Duration
+	keepaliveMaxMissed int
+	lastRxMu           sync.Mutex
+	lastRxPDU          time.Time
+	lastPingMu         sync.Mutex
+	lastPingSent       time.Time
+	keepaliveMissed    int32
+	pendingMu          sync.Mutex
+	pendingAcks        map[uint32]chan pdu.PDU
+	deliveryHook       func(gateway, messageID, status string)
 }
 
 type CloseSignals struct {
-    streamClose   chan struct{}
     closed        bool
 	mu            sync.Mutex
 }
@@ -111,16 +127,7 @@ func WithResponseWriter(responseWriter *response.Writer) Option {
 
 func NewSession(cfg config.Provider, h *PDUHandler, options ...Option) (*Session, error) {
 	session := &Session{
-		logger: 		  log.Logger{
-			Level:      log.InfoLevel,
-			TimeFormat: "15:04:05",
-			Caller:     1,
-			Writer: &log.FileWriter{
-				Filename:   "logs/smpp/smpp.log",
-				MaxBackups: 14,
-				LocalTime:  false,
-			},
-		},
+		logger:            defaultLogger(),
 		gateway:           cfg.Name,
 		startTime:         time.Now(),
 		concatenated:      make(map[uint8][]string),
@@ -142,13 +149,10 @@ func NewSession(cfg config.Provider, h *PDUHandler, options ...Option) (*Session
 		portedStream: 		NewStream(50),
 		resendStream:       NewStream(50),
 
-		/// To be changed
 		shutdown: CloseSignals{
-			streamClose: make(chan struct{}),
 			closed: false,
 			mu: sync.Mutex{},
 		},
-		portGateways:      []string{"zain", "mobily", "stc"},
 		smppSessions:      SMPPSessions{},
 	}
 
@@ -163,6 +167,14 @@ func NewSession(cfg config.Provider, h *PDUHandler, options ...Option) (*Session
 		SystemType: cfg.SystemType,
 	}
 
+	if session.tlsCfg != nil {
+		tlsConfig, err := buildTLSConfig(session.tlsCfg)
+		if err != nil {
+			return nil, err
+		}
+		session.tlsConfig = tlsConfig
+	}
+
 	return session, nil
 }
 
@@ -171,18 +183,21 @@ func (s *Session) Start(ctx context.Context) error {
 	maxDelay := 10 * time.Second
 	factor := 2.0
 
-	s.ctx = ctx
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.watchSIGHUP()
+	s.watchKeepalive()
 	for retries := 0; retries <= s.maxRetries; retries++ {
 		select {
-		case <-ctx.Done():
+		case <-s.ctx.Done():
 			return errors.New("session creation stopped")
 		default:
 			if err := s.connectSessions(); err != nil {
 				delay := calculateBackoff(initialDelay, maxDelay, factor, retries)
-				s.logger.Error().Err(err).Str("provider", s.gateway).Msg("Failed to create session")
-				s.logger.Info().Msgf("Retrying in (Backoff) : %v", delay)
+				s.logger.Error("Failed to create session", s.sessionFields(zap.Error(err))...)
+				s.logger.Info("Retrying in (Backoff)", s.sessionFields(zap.Duration("delay", delay))...)
 				time.Sleep(delay)
 			} else {
+				s.replayOutbox()
 				return nil
 			}
 		}
@@ -193,43 +208,62 @@ func (s *Session) Start(ctx context.Context) error {
 
 func (s *Session) connectSessions() error {
 	var err error
+	dialer := s.dialer()
+
 	switch s.sessionType {
 	case Transceiver:
 		s.smppSessions.transceiver, err = gosmpp.NewSession(
-			gosmpp.TRXConnector(gosmpp.NonTLSDialer, s.auth),
+			gosmpp.TRXConnector(dialer, s.auth),
 			s.getSettings(),
 			s.rebindingInterval,
 		)
 		if err != nil {
-			return err
+			return s.tlsWrapErr(err)
 		}
 	default:
 		s.smppSessions.receiver, err = gosmpp.NewSession(
-			gosmpp.RXConnector(gosmpp.NonTLSDialer, s.auth),
+			gosmpp.RXConnector(dialer, s.auth),
 			s.getSettings(),
 			s.rebindingInterval,
 		)
 		if err != nil {
-			return err
+			return s.tlsWrapErr(err)
 		}
 
 		s.smppSessions.transmitter, err = gosmpp.NewSession(
-			gosmpp.TXConnector(gosmpp.NonTLSDialer, s.auth),
+			gosmpp.TXConnector(dialer, s.auth),
 			s.getSettings(),
 			s.rebindingInterval,
 		)
 		if err != nil {
-			return err
+			return s.tlsWrapErr(err)
 		}
 	}
 
+	if s.tlsConfig != nil {
+		metrics.TLSSessions.WithLabelValues(s.gateway).Inc()
+		s.observeCertExpiry()
+	} else {
+		metrics.PlaintextSessions.WithLabelValues(s.gateway).Inc()
+	}
+
 	metrics.ActiveSessions.Inc()
 	return nil
 }
 
+// tlsWrapErr annotates a connect error with the TLS handshake context when
+// the session is bound for SMPPS, so the Start backoff loop logs why a
+// secure bind failed rather than a bare dial error.
+func (s *Session) tlsWrapErr(err error) error {
+	if s.tlsConfig == nil {
+		return err
+	}
+	return fmt.Errorf("SMPPS handshake with %s: %w", s.gateway, err)
+}
+
 func (s *Session) getSettings() gosmpp.Settings {
 	return gosmpp.Settings{
-		EnquireLink:      s.enquireLink,
+		EnquireLink:      s.effectiveEnquireLink(),
 		ReadTimeout:      s.readTimeout,
 		OnAllPDU:         handlePDU(s),
 		OnSubmitError:    s.handleSubmitError,
@@ -240,31 +274,38 @@ func (s *Session) getSettings() gosmpp.Settings {
 }
 
 func (s *Session) handleSubmitError(p pdu.PDU, err error) {
-	s.logger.Error().Err(err).Msg("SubmitPDU error")
+	fields := append(pduFields(p), zap.Error(err), zap.String("pdu", fmt.Sprintf("%T", p)))
+	s.logger.Error("SubmitPDU error", s.sessionFields(fields...)...)
+
+	if _, ok := p.(*pdu.SubmitSM); ok {
+		s.handleSubmitRetry(int32(p.GetSequenceNumber()))
+	}
 }
 
 func (s *Session) handleReceivingError(err error) {
-	s.logger.Error().Err(err).Msg("Receiving PDU/Network error")
+	s.logger.Error("Receiving PDU/Network error", s.sessionFields(zap.Error(err))...)
 }
 
 func (s *Session) handleRebindingError(err error) {
 	metrics.SessionDuration.Observe(time.Since(s.startTime).Seconds())
-	s.logger.Error().Err(err).Msg("Rebinding error")
+	s.logger.Error("Rebinding error", s.sessionFields(zap.Error(err))...)
 }
 
 func (s *Session) handleClosed(state gosmpp.State) {
 	metrics.ActiveSessions.Dec()
-	s.logger.Info().Msg("Session closed")	
+	s.logger.Info("Session closed", s.sessionFields()...)
 }
 
 func handlePDU(s *Session) func(pdu.PDU) (pdu.PDU, bool) {
 	return func(p pdu.PDU) (pdu.PDU, bool) {
+		s.touchLastRxPDU()
+
 		switch pd := p.(type) {
 		case *pdu.BindResp:
 
 			// Handle BindResp if needed
 		case *pdu.Unbind:
-			s.logger.Info().Msg("Unbind Received")
+			s.logger.Info("Unbind Received", s.sessionFields(pduFields(pd)...)...)
 			metrics.ActiveSessions.Dec()
 			return pd.GetResponse(), true
 		case *pdu.UnbindResp:
@@ -273,6 +314,12 @@ func handlePDU(s *Session) func(pdu.PDU) (pdu.PDU, bool) {
 		case *pdu.SubmitSMResp:
 			s.handleSubmitSMResp(pd)
 			return pd.GetResponse(), false
+		case *pdu.QuerySMResp:
+			s.deliverPendingAck(pd)
+		case *pdu.CancelSMResp:
+			s.deliverPendingAck(pd)
+		case *pdu.ReplaceSMResp:
+			s.deliverPendingAck(pd)
 		case *pdu.GenericNack:
 			// Handle GenericNack if needed
 		case *pdu.EnquireLinkResp:
@@ -307,9 +354,76 @@ func (s *Session) StreamResend() <-chan queue.MessageData {
 	return s.resendStream.stream
 }
 
+// PortedStream exposes messages destined for this session's gateway that
+// may need rerouting because the number has since been ported to a
+// different carrier. A Router drains this to decide whether to carry the
+// message here or hand it to whichever gateway now owns the number.
+func (s *Session) PortedStream() <-chan queue.MessageData {
+	return s.portedStream.stream
+}
+
+// Context returns the session's root context, derived from the one passed
+// to Start. Outstanding submits, resends and delivery receipt handlers
+// should derive their own contexts from this one so Stop can abort them.
+func (s *Session) Context() context.Context {
+	return s.ctx
+}
+
+// Done reports when the session's root context has been cancelled, letting
+// callers observe termination without polling Stop.
+func (s *Session) Done() <-chan struct{} {
+	if s.ctx == nil {
+		return nil
+	}
+	return s.ctx.Done()
+}
+
+// acquireOutstanding reserves a slot for an in-flight submit, blocking until
+// one is free. It aborts early if either the caller's context or the
+// session's root context is cancelled, so a Stop mid-send doesn't hang.
+func (s *Session) acquireOutstanding(ctx context.Context) error {
+	if !s.hasOutstanding {
+		return nil
+	}
+	select {
+	case s.outstandingCh <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+// releaseOutstanding frees the slot reserved by acquireOutstanding.
+func (s *Session) releaseOutstanding() {
+	if !s.hasOutstanding {
+		return
+	}
+	select {
+	case <-s.outstandingCh:
+	default:
+	}
+}
+
+// drainOutstanding empties outstandingCh so Stop doesn't wait on slots held
+// by submits that were aborted via context cancellation.
+func (s *Session) drainOutstanding() {
+	for {
+		select {
+		case <-s.outstandingCh:
+		default:
+			return
+		}
+	}
+}
+
 func (s *Session) Stop() {
-	<-s.shutdown.streamClose
-	
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.drainOutstanding()
+
 	s.wg.Wait()
 
 	if s.smppSessions.transceiver != nil {
@@ -322,8 +436,6 @@ func (s *Session) Stop() {
 		s.smppSessions.transmitter.Close()
 	}
 
-	time.Sleep(1 * time.Second)
-
 	// close(s.portedChannel)
 	s.portedStream.Close()
 	s.resendStream.Close()
@@ -339,8 +451,15 @@ func (s *Session) Stop() {
 
 	metrics.SessionDuration.Observe(time.Since(s.startTime).Seconds())
 
+	// deliveryWg.Wait above already guarantees every in-flight Write (which
+	// calls WriteResponse synchronously) has returned before we get here.
 	if s.responseWriter != nil {
-		time.Sleep(1 * time.Second)
 		s.responseWriter.Close()
 	}
+
+	if s.outbox != nil {
+		if err := s.outbox.Close(); err != nil {
+			s.logger.Error("Failed to close outbox", s.sessionFields(zap.Error(err))...)
+		}
+	}
 }
\ No newline at end of file
@@ -0,0 +1,117 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newOutstandingTestSession() *Session {
+	return &Session{
+		hasOutstanding: true,
+		outstandingCh:  make(chan struct{}, 1),
+	}
+}
+
+func TestAcquireOutstandingUnblocksOnSessionContextCancel(t *testing.T) {
+	s := newOutstandingTestSession()
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	// Fill the single slot so the next acquire has to block.
+	if err := s.acquireOutstanding(context.Background()); err != nil {
+		t.Fatalf("first acquireOutstanding: %v", err)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.acquireOutstanding(context.Background())
+	}()
+
+	select {
+	case err := <-errCh:
+		t.Fatalf("acquireOutstanding returned %v before the session context was cancelled", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	s.cancel()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("acquireOutstanding error = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("acquireOutstanding did not unblock after the session context was cancelled")
+	}
+}
+
+func TestAcquireOutstandingUnblocksOnCallerContextCancel(t *testing.T) {
+	s := newOutstandingTestSession()
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	defer s.cancel()
+
+	if err := s.acquireOutstanding(context.Background()); err != nil {
+		t.Fatalf("first acquireOutstanding: %v", err)
+	}
+
+	callerCtx, callerCancel := context.WithCancel(context.Background())
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.acquireOutstanding(callerCtx)
+	}()
+
+	callerCancel()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("acquireOutstanding error = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("acquireOutstanding did not unblock after the caller context was cancelled")
+	}
+}
+
+func TestReleaseOutstandingFreesSlotForNextAcquire(t *testing.T) {
+	s := newOutstandingTestSession()
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	defer s.cancel()
+
+	if err := s.acquireOutstanding(context.Background()); err != nil {
+		t.Fatalf("acquireOutstanding: %v", err)
+	}
+	s.releaseOutstanding()
+
+	done := make(chan error, 1)
+	go func() { done <- s.acquireOutstanding(context.Background()) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("acquireOutstanding after release: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("acquireOutstanding blocked after releaseOutstanding freed the slot")
+	}
+}
+
+func TestDoneClosedAfterCancel(t *testing.T) {
+	s := &Session{}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	select {
+	case <-s.Done():
+		t.Fatal("Done() channel closed before cancel was called")
+	default:
+	}
+
+	s.cancel()
+
+	select {
+	case <-s.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Done() did not close after cancel")
+	}
+}
@@ -0,0 +1,390 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/linxGnu/gosmpp"
+	"github.com/linxGnu/gosmpp/pdu"
+	"github.com/rixtrayker/demo-smpp/internal/dtos"
+	"github.com/rixtrayker/demo-smpp/internal/outbox"
+	"go.uber.org/zap"
+)
+
+// ErrNoActiveTransport is returned by the submit path when neither a
+// transceiver nor a transmitter bind is currently up to carry it.
+var ErrNoActiveTransport = errors.New("session: no active transport to submit through")
+
+// WithDeliveryReceiptHook registers a callback invoked with the gateway,
+// SMSC message id, and status of every delivery receipt HandleDeliverSM
+// correlates, e.g. so an RPC server can fan it out to DeliveryReceipts
+// stream subscribers without this package needing to know RPC exists.
+func WithDeliveryReceiptHook(hook func(gateway, messageID, status string)) Option {
+	return func(s *Session) {
+		s.deliveryHook = hook
+	}
+}
+
+// activeTransport picks whichever bind can carry an outbound PDU: the
+// transceiver if this session is bound TRX, otherwise the transmitter.
+func (s *Session) activeTransport() *gosmpp.Session {
+	if s.smppSessions.transceiver != nil {
+		return s.smppSessions.transceiver
+	}
+	return s.smppSessions.transmitter
+}
+
+// registerPendingAck reserves a slot for the response to the PDU at seq,
+// fulfilled by deliverPendingAck once handlePDU sees the matching *Resp.
+// Idempotent: if seq is already registered (the submit path registers it
+// right after the PDU goes out), the existing channel is returned rather
+// than replaced, so a resp already delivered to it can't be dropped.
+func (s *Session) registerPendingAck(seq uint32) chan pdu.PDU {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+	if s.pendingAcks == nil {
+		s.pendingAcks = make(map[uint32]chan pdu.PDU)
+	}
+	if ch, ok := s.pendingAcks[seq]; ok {
+		return ch
+	}
+	ch := make(chan pdu.PDU, 1)
+	s.pendingAcks[seq] = ch
+	return ch
+}
+
+// deliverPendingAck hands p to whatever SubmitAndAwait/awaitAck call is
+// still waiting on its sequence number, if any. Returns false if nothing
+// was waiting (e.g. a retransmitted resp, or one that outlived its caller's
+// context).
+func (s *Session) deliverPendingAck(p pdu.PDU) bool {
+	seq := p.GetSequenceNumber()
+	s.pendingMu.Lock()
+	ch, ok := s.pendingAcks[seq]
+	if ok {
+		delete(s.pendingAcks, seq)
+	}
+	s.pendingMu.Unlock()
+	if !ok {
+		return false
+	}
+	ch <- p
+	return true
+}
+
+// awaitAck blocks for the response registered at seq, aborting early on
+// either the caller's context or the session's own shutdown.
+func (s *Session) awaitAck(ctx context.Context, seq uint32, ch chan pdu.PDU) (pdu.PDU, error) {
+	defer func() {
+		s.pendingMu.Lock()
+		delete(s.pendingAcks, seq)
+		s.pendingMu.Unlock()
+	}()
+
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+}
+
+// newSubmitSM builds a SubmitSM for sender -> number carrying text. Kept as
+// a single helper so the outbox Put below and the PDU handed to gosmpp
+// always agree on what was actually sent.
+func newSubmitSM(sender, number, text string) *pdu.SubmitSM {
+	sm := pdu.NewSubmitSM().(*pdu.SubmitSM)
+	sm.SourceAddr = sender
+	sm.DestAddr = number
+	sm.Message = text
+	return sm
+}
+
+// submitSM does the actual gosmpp submit and, immediately on success,
+// registers the pending-ack channel for its sequence number — before the
+// outbox write, the messagesStatus update, or any of that — and returns that
+// channel. A SubmitSMResp can come back from the SMSC the instant Submit
+// returns, so the registration can't wait for control to propagate back up
+// to a caller like SubmitAndAwait; returning the channel directly also means
+// that caller awaits the exact one registerPendingAck created here rather
+// than risking a second registration racing deliverPendingAck for the same
+// sequence number.
+func (s *Session) submitSM(ctx context.Context, transport *gosmpp.Session, sender, number, text string) (*pdu.SubmitSM, chan pdu.PDU, error) {
+	if err := s.acquireOutstanding(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	sm := newSubmitSM(sender, number, text)
+	if err := transport.Submit(sm); err != nil {
+		s.releaseOutstanding()
+		return nil, nil, fmt.Errorf("submit to %s: %w", s.gateway, err)
+	}
+	ch := s.registerPendingAck(sm.GetSequenceNumber())
+
+	seq := int32(sm.GetSequenceNumber())
+	s.mu.Lock()
+	s.messagesStatus[seq] = &MessageStatus{
+		startTime: time.Now(),
+		Sender:    sender,
+		Text:      text,
+		Number:    number,
+		Status:    "submitted",
+	}
+	s.mu.Unlock()
+
+	if s.outbox != nil {
+		entry := outbox.Entry{
+			Sequence:   seq,
+			Sender:     sender,
+			Text:       text,
+			Number:     number,
+			Gateway:    s.gateway,
+			SubmitTime: time.Now(),
+		}
+		if err := s.outbox.Put(entry); err != nil {
+			s.logger.Error("Failed to write outbox entry", s.sessionFields(zap.Error(err), zap.Int32("sequence", seq))...)
+		}
+	}
+
+	return sm, ch, nil
+}
+
+// SubmitSM hands text from sender to number off to gosmpp and, once the
+// transport has accepted the PDU, writes it to the outbox so a crash before
+// the SubmitSMResp doesn't lose it; a crash between that accept and the
+// outbox write leaves no entry. Returns once the PDU has been accepted by
+// the transport (not once the SMSC has acknowledged it — use
+// SubmitAndAwait for that).
+func (s *Session) SubmitSM(ctx context.Context, sender, number, text string) (*pdu.SubmitSM, error) {
+	transport := s.activeTransport()
+	if transport == nil {
+		return nil, ErrNoActiveTransport
+	}
+
+	sm, _, err := s.submitSM(ctx, transport, sender, number, text)
+	return sm, err
+}
+
+// SubmitAndAwait is SubmitSM plus blocking for the matching SubmitSMResp,
+// for callers (the RPC server, the Router's failover path) that need the
+// SMSC's command status before deciding whether to retry elsewhere.
+func (s *Session) SubmitAndAwait(ctx context.Context, sender, number, text string) (*pdu.SubmitSMResp, error) {
+	transport := s.activeTransport()
+	if transport == nil {
+		return nil, ErrNoActiveTransport
+	}
+
+	sm, ch, err := s.submitSM(ctx, transport, sender, number, text)
+	if err != nil {
+		return nil, err
+	}
+	defer s.releaseOutstanding()
+
+	resp, err := s.awaitAck(ctx, sm.GetSequenceNumber(), ch)
+	if err != nil {
+		return nil, err
+	}
+	submitResp, ok := resp.(*pdu.SubmitSMResp)
+	if !ok {
+		return nil, fmt.Errorf("session: unexpected response type %T for SubmitSM", resp)
+	}
+	return submitResp, nil
+}
+
+// QuerySM asks the SMSC for the current status of a previously submitted
+// message, correlating by its SMSC-assigned message id.
+func (s *Session) QuerySM(ctx context.Context, messageID, sourceAddr string) (*pdu.QuerySMResp, error) {
+	transport := s.activeTransport()
+	if transport == nil {
+		return nil, ErrNoActiveTransport
+	}
+
+	q := pdu.NewQuerySM().(*pdu.QuerySM)
+	q.MessageID = messageID
+	q.SourceAddr = sourceAddr
+	if err := transport.Submit(q); err != nil {
+		return nil, fmt.Errorf("query to %s: %w", s.gateway, err)
+	}
+
+	ch := s.registerPendingAck(q.GetSequenceNumber())
+	resp, err := s.awaitAck(ctx, q.GetSequenceNumber(), ch)
+	if err != nil {
+		return nil, err
+	}
+	queryResp, ok := resp.(*pdu.QuerySMResp)
+	if !ok {
+		return nil, fmt.Errorf("session: unexpected response type %T for QuerySM", resp)
+	}
+	return queryResp, nil
+}
+
+// CancelSM asks the SMSC to drop a previously submitted, undelivered
+// message.
+func (s *Session) CancelSM(ctx context.Context, messageID, sourceAddr, destAddr string) (*pdu.CancelSMResp, error) {
+	transport := s.activeTransport()
+	if transport == nil {
+		return nil, ErrNoActiveTransport
+	}
+
+	c := pdu.NewCancelSM().(*pdu.CancelSM)
+	c.MessageID = messageID
+	c.SourceAddr = sourceAddr
+	c.DestAddr = destAddr
+	if err := transport.Submit(c); err != nil {
+		return nil, fmt.Errorf("cancel to %s: %w", s.gateway, err)
+	}
+
+	ch := s.registerPendingAck(c.GetSequenceNumber())
+	resp, err := s.awaitAck(ctx, c.GetSequenceNumber(), ch)
+	if err != nil {
+		return nil, err
+	}
+	cancelResp, ok := resp.(*pdu.CancelSMResp)
+	if !ok {
+		return nil, fmt.Errorf("session: unexpected response type %T for CancelSM", resp)
+	}
+	return cancelResp, nil
+}
+
+// ReplaceSM asks the SMSC to swap the body of a previously submitted,
+// undelivered message for newText.
+func (s *Session) ReplaceSM(ctx context.Context, messageID, sourceAddr, newText string) (*pdu.ReplaceSMResp, error) {
+	transport := s.activeTransport()
+	if transport == nil {
+		return nil, ErrNoActiveTransport
+	}
+
+	r := pdu.NewReplaceSM().(*pdu.ReplaceSM)
+	r.MessageID = messageID
+	r.SourceAddr = sourceAddr
+	r.Message = newText
+	if err := transport.Submit(r); err != nil {
+		return nil, fmt.Errorf("replace to %s: %w", s.gateway, err)
+	}
+
+	ch := s.registerPendingAck(r.GetSequenceNumber())
+	resp, err := s.awaitAck(ctx, r.GetSequenceNumber(), ch)
+	if err != nil {
+		return nil, err
+	}
+	replaceResp, ok := resp.(*pdu.ReplaceSMResp)
+	if !ok {
+		return nil, fmt.Errorf("session: unexpected response type %T for ReplaceSM", resp)
+	}
+	return replaceResp, nil
+}
+
+// handleSubmitSMResp correlates a SubmitSMResp back to its outbox entry
+// (recording the SMSC-assigned message id so HandleDeliverSM can later
+// correlate a delivery receipt to it), updates the in-memory status map,
+// and wakes up any SubmitAndAwait call still waiting on it.
+func (s *Session) handleSubmitSMResp(resp *pdu.SubmitSMResp) {
+	seq := resp.GetSequenceNumber()
+
+	s.mu.Lock()
+	status, ok := s.messagesStatus[int32(seq)]
+	if ok {
+		status.MessageID = resp.MessageID
+		status.Status = resp.CommandStatus.String()
+	}
+	s.mu.Unlock()
+
+	if s.outbox != nil {
+		if err := s.outbox.UpdateMessageID(int32(seq), resp.MessageID); err != nil {
+			s.logger.Error("Failed to correlate SubmitSMResp with outbox entry",
+				s.sessionFields(zap.Error(err), zap.Uint32("sequence", seq))...)
+		}
+	}
+
+	if !s.deliverPendingAck(resp) {
+		s.logger.Debug("SubmitSMResp had no waiting caller", s.sessionFields(pduFields(resp)...)...)
+	}
+}
+
+// handleSubmitRetry records a failed submit against the outbox's retry
+// count, evicting it to the dead-letter bucket once it exceeds maxRetries
+// instead of resending it forever.
+func (s *Session) handleSubmitRetry(seq int32) {
+	if s.outbox == nil {
+		return
+	}
+	deadLettered, err := s.outbox.RecordRetry(seq)
+	if err != nil {
+		s.logger.Error("Failed to record outbox retry", s.sessionFields(zap.Error(err), zap.Int32("sequence", seq))...)
+		return
+	}
+	if deadLettered {
+		s.logger.Error("Submit exceeded max retries, dead-lettered", s.sessionFields(zap.Int32("sequence", seq))...)
+	}
+}
+
+// HandleDeliverSM handles an inbound DeliverSM, which carries either a
+// delivery receipt for one of our own SubmitSMs or an MO (mobile
+// originated) message. Delivery receipts are correlated back to the
+// outbox entry they acknowledge and removed from the WAL; everything is
+// also handed to Write so the configured response.Writer sees it.
+func (s *Session) HandleDeliverSM(pd *pdu.DeliverSM) {
+	defer s.deliveryWg.Done()
+
+	messageID, status, delivered := parseDeliveryReceipt(pd.Message)
+	if delivered {
+		if s.outbox != nil {
+			if err := s.outbox.MarkDelivered(messageID); err != nil && !errors.Is(err, outbox.ErrNotFound) {
+				s.logger.Error("Failed to mark outbox entry delivered",
+					s.sessionFields(zap.Error(err), zap.String("message_id", messageID))...)
+			}
+		}
+		if s.deliveryHook != nil {
+			s.deliveryHook(s.gateway, messageID, status)
+		}
+	}
+
+	s.Write(&dtos.ReceiveLog{
+		Gateway:   s.gateway,
+		MessageID: messageID,
+		Text:      pd.Message,
+	})
+}
+
+// parseDeliveryReceipt extracts the "id:<message_id>" and "stat:<status>"
+// fields SMSCs encode a delivery receipt's short message with, reporting
+// whether text actually looked like a delivery receipt at all (as opposed
+// to an MO message, which has neither field).
+func parseDeliveryReceipt(text string) (messageID, status string, delivered bool) {
+	id, ok := deliveryReceiptField(text, "id:")
+	if !ok {
+		return "", "", false
+	}
+	stat, _ := deliveryReceiptField(text, "stat:")
+	return id, stat, true
+}
+
+// deliveryReceiptField returns the space-delimited value following prefix
+// in text, e.g. deliveryReceiptField("id:1 stat:DELIVRD", "stat:") ==
+// "DELIVRD".
+func deliveryReceiptField(text, prefix string) (string, bool) {
+	idx := -1
+	for i := 0; i+len(prefix) <= len(text); i++ {
+		if text[i:i+len(prefix)] == prefix {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return "", false
+	}
+
+	rest := text[idx+len(prefix):]
+	end := len(rest)
+	for i, c := range rest {
+		if c == ' ' {
+			end = i
+			break
+		}
+	}
+	return rest[:end], true
+}
@@ -0,0 +1,43 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/linxGnu/gosmpp/pdu"
+)
+
+func TestRegisterPendingAckIsIdempotent(t *testing.T) {
+	s := &Session{}
+
+	first := s.registerPendingAck(7)
+	second := s.registerPendingAck(7)
+
+	if first != second {
+		t.Fatal("registerPendingAck returned a different channel on a second call for the same sequence number")
+	}
+}
+
+func TestDeliverPendingAckFindsPreRegisteredCaller(t *testing.T) {
+	s := &Session{}
+
+	// submitSM registers the pending ack itself, right after the PDU goes
+	// out, before a waiting caller like SubmitAndAwait ever touches
+	// pendingAcks — simulate that ordering directly.
+	sm := pdu.NewSubmitSM().(*pdu.SubmitSM)
+	ch := s.registerPendingAck(sm.GetSequenceNumber())
+
+	resp := sm.GetResponse().(*pdu.SubmitSMResp)
+	resp.MessageID = "abc-123"
+	if !s.deliverPendingAck(resp) {
+		t.Fatal("deliverPendingAck found no waiting caller despite the pre-registration")
+	}
+
+	select {
+	case got := <-ch:
+		if got != pdu.PDU(resp) {
+			t.Fatalf("delivered %v, want %v", got, resp)
+		}
+	default:
+		t.Fatal("pre-registered channel never received the delivered resp")
+	}
+}
@@ -0,0 +1,140 @@
+package session
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/linxGnu/gosmpp"
+	"github.com/rixtrayker/demo-smpp/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// TLSConfig describes the material needed to dial an SMPPS (SMPP over TLS)
+// endpoint. It is intentionally a plain data struct so it can be loaded from
+// config.Provider and passed to WithTLS without requiring callers to build a
+// crypto/tls.Config themselves.
+type TLSConfig struct {
+	CertFile           string
+	KeyFile            string
+	CAFile             string
+	ServerName         string
+	InsecureSkipVerify bool
+	MinVersion         uint16
+	MaxVersion         uint16
+	CipherSuites       []uint16
+}
+
+// WithTLS enables SMPPS for the session, dialing with the given TLS
+// material instead of gosmpp.NonTLSDialer.
+func WithTLS(cfg TLSConfig) Option {
+	return func(s *Session) {
+		s.tlsCfg = &cfg
+	}
+}
+
+// buildTLSConfig turns a TLSConfig into a *tls.Config, loading the
+// certificate/key pair and, if given, a CA bundle for verifying the SMSC.
+func buildTLSConfig(cfg *TLSConfig) (*tls.Config, error) {
+	tlsConf := &tls.Config{
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		MinVersion:         cfg.MinVersion,
+		MaxVersion:         cfg.MaxVersion,
+		CipherSuites:       cfg.CipherSuites,
+	}
+
+	if cfg.CertFile != "" && cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load SMPPS keypair: %w", err)
+		}
+		tlsConf.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.CAFile != "" {
+		caBytes, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read SMPPS CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caBytes) {
+			return nil, fmt.Errorf("no certificates parsed from %s", cfg.CAFile)
+		}
+		tlsConf.RootCAs = pool
+	}
+
+	return tlsConf, nil
+}
+
+// observeCertExpiry reports the leaf certificate's expiry as a Prometheus
+// gauge so operators can alert before an SMPPS cert lapses.
+func (s *Session) observeCertExpiry() {
+	s.mu.Lock()
+	tlsConfig := s.tlsConfig
+	s.mu.Unlock()
+
+	if tlsConfig == nil || len(tlsConfig.Certificates) == 0 {
+		return
+	}
+	leaf := tlsConfig.Certificates[0].Leaf
+	if leaf == nil {
+		parsed, err := x509.ParseCertificate(tlsConfig.Certificates[0].Certificate[0])
+		if err != nil {
+			s.logger.Error("Failed to parse TLS leaf certificate", s.sessionFields(zap.Error(err))...)
+			return
+		}
+		leaf = parsed
+	}
+	metrics.TLSCertExpiry.WithLabelValues(s.gateway).Set(float64(leaf.NotAfter.Unix()))
+}
+
+// watchSIGHUP reloads the TLS certificate/key on SIGHUP without dropping the
+// in-flight SMPP sessions, the same way long-lived network services rotate
+// certificate material in place.
+func (s *Session) watchSIGHUP() {
+	if s.tlsCfg == nil {
+		return
+	}
+
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	go func() {
+		for {
+			select {
+			case <-s.ctx.Done():
+				signal.Stop(sighup)
+				return
+			case <-sighup:
+				newConf, err := buildTLSConfig(s.tlsCfg)
+				if err != nil {
+					s.logger.Error("Failed to reload TLS certificate on SIGHUP", s.sessionFields(zap.Error(err))...)
+					continue
+				}
+				s.mu.Lock()
+				s.tlsConfig = newConf
+				s.mu.Unlock()
+				s.observeCertExpiry()
+				s.logger.Info("Reloaded TLS certificate on SIGHUP", s.sessionFields()...)
+			}
+		}
+	}()
+}
+
+// dialer adapts s.tlsConfig to a gosmpp.TLSDialer, falling back to the
+// plaintext dialer when TLS isn't configured for this session. Takes s.mu
+// because watchSIGHUP can swap s.tlsConfig concurrently with a reconnect.
+func (s *Session) dialer() gosmpp.Dialer {
+	s.mu.Lock()
+	tlsConfig := s.tlsConfig
+	s.mu.Unlock()
+
+	if tlsConfig == nil {
+		return gosmpp.NonTLSDialer
+	}
+	return gosmpp.TLSDialer(tlsConfig)
+}